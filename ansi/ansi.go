@@ -0,0 +1,556 @@
+// Package ansi implements a small virtual-terminal emulator that turns a
+// stream of raw PTY bytes — CSI cursor movement, erase-in-line/display,
+// SGR styling, and alternate-screen switches — into a flat, styled text
+// snapshot suitable for Freeze's renderer. It's deliberately not a full
+// terminal emulator: just enough of the VT100/xterm subset that real
+// shells, editors, and TUIs rely on to render legibly.
+package ansi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode selects how an executed command's output is post-processed before
+// it's handed to Freeze's renderer.
+type Mode string
+
+const (
+	// ModeRaw leaves escape sequences in the PTY bytes, as Freeze always has.
+	ModeRaw Mode = "raw"
+	// ModePlain strips escape sequences entirely, leaving plain text.
+	ModePlain Mode = "plain"
+	// ModeANSI renders a clean snapshot of the terminal's final frame,
+	// with per-cell SGR state re-emitted as minimal escape sequences.
+	ModeANSI Mode = "ansi"
+)
+
+// style is the SGR state a cell was written with.
+type style struct {
+	fg, bg    int // SGR color code, 0 if unset
+	bold      bool
+	italic    bool
+	underline bool
+	reverse   bool
+}
+
+func (s style) isZero() bool { return s == style{} }
+
+type cell struct {
+	r     rune
+	style style
+}
+
+// VTerm is a minimal virtual terminal: a grid of cells that cursor
+// movement, erase, and SGR sequences are applied to, plus an alternate
+// screen buffer. Only whichever of the two screens is active at EOF is
+// rendered; the other is discarded.
+type VTerm struct {
+	width, height int
+	row, col      int
+	cur           style
+
+	main, alt []line
+	altScreen bool
+
+	pending []byte // partial escape sequence carried across writes
+}
+
+type line []cell
+
+// New creates a VTerm sized to match the pty's geometry (as returned by
+// term.GetSize).
+func New(width, height int) *VTerm {
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return &VTerm{
+		width:  width,
+		height: height,
+		main:   newScreen(width, height),
+		alt:    newScreen(width, height),
+	}
+}
+
+func newScreen(width, height int) []line {
+	s := make([]line, height)
+	for i := range s {
+		s[i] = make(line, width)
+	}
+	return s
+}
+
+func (v *VTerm) screen() []line {
+	if v.altScreen {
+		return v.alt
+	}
+	return v.main
+}
+
+// Write feeds raw PTY bytes into the terminal, advancing cursor position
+// and cell state. It never returns an error; malformed sequences are
+// skipped rather than failing the stream.
+func (v *VTerm) Write(p []byte) (int, error) {
+	buf := append(v.pending, p...)
+	v.pending = nil
+
+	i := 0
+	for i < len(buf) {
+		b := buf[i]
+		switch {
+		case b == 0x1b: // ESC
+			n, complete := v.handleEscape(buf[i:])
+			if !complete {
+				v.pending = append([]byte{}, buf[i:]...)
+				i = len(buf)
+				break
+			}
+			i += n
+		case b == '\r':
+			v.col = 0
+			i++
+		case b == '\n':
+			v.newline()
+			i++
+		case b == '\b':
+			if v.col > 0 {
+				v.col--
+			}
+			i++
+		case b == '\t':
+			v.col = (v.col/8 + 1) * 8
+			if v.col >= v.width {
+				v.newline()
+			}
+			i++
+		default:
+			r, size := decodeRune(buf[i:])
+			v.put(r)
+			i += size
+		}
+	}
+	return len(p), nil
+}
+
+func (v *VTerm) put(r rune) {
+	if v.row < 0 || v.row >= v.height {
+		return
+	}
+	if v.col >= v.width {
+		v.newline()
+	}
+	v.screen()[v.row][v.col] = cell{r: r, style: v.cur}
+	v.col++
+}
+
+func (v *VTerm) newline() {
+	v.col = 0
+	if v.row < v.height-1 {
+		v.row++
+		return
+	}
+	// Scroll the active screen up one line.
+	s := v.screen()
+	copy(s, s[1:])
+	s[v.height-1] = make(line, v.width)
+}
+
+// scanEscape identifies the extent of the escape sequence starting at
+// buf[0]=='\x1b' — CSI, OSC (terminated by BEL or ST), a 3-byte charset
+// designator, or any other 2-byte form — without applying its effect.
+// It returns the number of bytes the sequence occupies, whether it was
+// complete (false means buf was truncated and should be retried once
+// more bytes arrive), and whether it was a CSI sequence (in which case n
+// includes the 2-byte "ESC [" prefix and the final byte, so the
+// parameters are buf[2:n-1] and the final byte is buf[n-1]).
+//
+// Both VTerm.handleEscape (which also applies CSI effects to the grid)
+// and Strip (which only needs to skip the bytes) are built on this, so
+// plain and ansi render modes agree on what counts as an escape sequence.
+func scanEscape(buf []byte) (n int, complete, isCSI bool) {
+	if len(buf) < 2 {
+		return 0, false, false
+	}
+	switch buf[1] {
+	case '[':
+		// CSI sequence: scan for the final byte, a letter in 0x40-0x7e.
+		for i := 2; i < len(buf); i++ {
+			if buf[i] >= 0x40 && buf[i] <= 0x7e {
+				return i + 1, true, true
+			}
+		}
+		return 0, false, false
+	case ']':
+		// OSC sequence (e.g. window-title), terminated by BEL or ST
+		// (ESC \). Its payload has no on-screen effect; swallow it whole
+		// rather than letting it fall through as visible text.
+		for i := 2; i < len(buf); i++ {
+			if buf[i] == 0x07 {
+				return i + 1, true, false
+			}
+			if buf[i] == 0x1b && i+1 < len(buf) && buf[i+1] == '\\' {
+				return i + 2, true, false
+			}
+		}
+		return 0, false, false
+	case '(', ')':
+		// Charset designator (e.g. ESC ( B): one selector byte follows.
+		if len(buf) < 3 {
+			return 0, false, false
+		}
+		return 3, true, false
+	default:
+		// Other two-byte sequences (ESC D, ESC M, ESC c, ...) have no
+		// on-screen effect this emulator models; just consume them.
+		return 2, true, false
+	}
+}
+
+// handleEscape parses one escape sequence starting at buf[0]=='\x1b',
+// applying it to the grid if it's a CSI sequence. It returns the number
+// of bytes consumed and whether the sequence was complete (false means
+// buf was a truncated sequence and should be retried once more bytes
+// arrive).
+func (v *VTerm) handleEscape(buf []byte) (int, bool) {
+	n, complete, isCSI := scanEscape(buf)
+	if !complete {
+		return 0, false
+	}
+	if isCSI {
+		v.applyCSI(string(buf[2:n-1]), buf[n-1])
+	}
+	return n, true
+}
+
+func (v *VTerm) applyCSI(params string, final byte) {
+	private := strings.HasPrefix(params, "?")
+	if private {
+		params = params[1:]
+	}
+	args := parseParams(params)
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] != 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'A':
+		v.row -= arg(0, 1)
+	case 'B':
+		v.row += arg(0, 1)
+	case 'C':
+		v.col += arg(0, 1)
+	case 'D':
+		v.col -= arg(0, 1)
+	case 'H', 'f':
+		v.row = arg(0, 1) - 1
+		v.col = arg(1, 1) - 1
+	case 'K':
+		v.eraseLine(arg(0, 0))
+	case 'J':
+		v.eraseDisplay(arg(0, 0))
+	case 'm':
+		v.applySGR(args)
+	case 'h', 'l':
+		if private {
+			v.applyPrivateMode(args, final == 'h')
+		}
+	}
+	v.clamp()
+}
+
+func (v *VTerm) clamp() {
+	if v.row < 0 {
+		v.row = 0
+	}
+	if v.row >= v.height {
+		v.row = v.height - 1
+	}
+	if v.col < 0 {
+		v.col = 0
+	}
+}
+
+// eraseLine implements EL: 0 = cursor to end, 1 = start to cursor, 2 = whole line.
+func (v *VTerm) eraseLine(mode int) {
+	if v.row < 0 || v.row >= v.height {
+		return
+	}
+	s := v.screen()[v.row]
+	switch mode {
+	case 1:
+		for i := 0; i <= v.col && i < len(s); i++ {
+			s[i] = cell{}
+		}
+	case 2:
+		for i := range s {
+			s[i] = cell{}
+		}
+	default:
+		for i := v.col; i < len(s); i++ {
+			s[i] = cell{}
+		}
+	}
+}
+
+// eraseDisplay implements ED: 0 = cursor to end of screen, 1 = start to
+// cursor, 2/3 = whole screen.
+func (v *VTerm) eraseDisplay(mode int) {
+	switch mode {
+	case 1:
+		for r := 0; r < v.row && r < v.height; r++ {
+			v.clearRow(r)
+		}
+		v.eraseLine(1)
+	case 2, 3:
+		for r := 0; r < v.height; r++ {
+			v.clearRow(r)
+		}
+	default:
+		v.eraseLine(0)
+		for r := v.row + 1; r < v.height; r++ {
+			v.clearRow(r)
+		}
+	}
+}
+
+func (v *VTerm) clearRow(r int) {
+	for i := range v.screen()[r] {
+		v.screen()[r][i] = cell{}
+	}
+}
+
+// applyPrivateMode handles the alternate-screen DEC private modes (1047,
+// 1049, and the older 47).
+func (v *VTerm) applyPrivateMode(args []int, set bool) {
+	for _, a := range args {
+		switch a {
+		case 47, 1047, 1049:
+			v.altScreen = set
+			if set {
+				v.alt = newScreen(v.width, v.height)
+				v.row, v.col = 0, 0
+			}
+		}
+	}
+}
+
+func (v *VTerm) applySGR(args []int) {
+	if len(args) == 0 {
+		args = []int{0}
+	}
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == 0:
+			v.cur = style{}
+		case a == 1:
+			v.cur.bold = true
+		case a == 3:
+			v.cur.italic = true
+		case a == 4:
+			v.cur.underline = true
+		case a == 7:
+			v.cur.reverse = true
+		case a == 22:
+			v.cur.bold = false
+		case a == 23:
+			v.cur.italic = false
+		case a == 24:
+			v.cur.underline = false
+		case a == 27:
+			v.cur.reverse = false
+		case a >= 30 && a <= 39:
+			if a == 38 && i+1 < len(args) {
+				code, consumed := parseExtendedColor(args[i:])
+				v.cur.fg = code
+				i += consumed
+			} else if a == 39 {
+				v.cur.fg = 0
+			} else {
+				v.cur.fg = a
+			}
+		case a >= 40 && a <= 49:
+			if a == 48 && i+1 < len(args) {
+				code, consumed := parseExtendedColor(args[i:])
+				v.cur.bg = code
+				i += consumed
+			} else if a == 49 {
+				v.cur.bg = 0
+			} else {
+				v.cur.bg = a
+			}
+		case a >= 90 && a <= 97:
+			v.cur.fg = a
+		case a >= 100 && a <= 107:
+			v.cur.bg = a
+		}
+	}
+}
+
+// parseExtendedColor handles 256-color (38;5;N) and truecolor (38;2;R;G;B)
+// SGR sequences, returning an opaque code to store and the number of
+// additional args consumed.
+func parseExtendedColor(args []int) (code, consumed int) {
+	if len(args) < 2 {
+		return 0, 0
+	}
+	switch args[1] {
+	case 5:
+		if len(args) >= 3 {
+			return 100000 + args[2], 2
+		}
+	case 2:
+		if len(args) >= 5 {
+			return 200000 + args[2]*65536 + args[3]*256 + args[4], 4
+		}
+	}
+	return 0, 1
+}
+
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ";")
+	args := make([]int, len(parts))
+	for i, p := range parts {
+		n, _ := strconv.Atoi(p)
+		args[i] = n
+	}
+	return args
+}
+
+func decodeRune(b []byte) (rune, int) {
+	for i, r := range string(b) {
+		if i > 0 {
+			break
+		}
+		if r == 0xfffd {
+			return ' ', 1
+		}
+		return r, len(string(r))
+	}
+	return ' ', 1
+}
+
+// Strip removes escape sequences (CSI, OSC, and charset designators) from
+// s, leaving plain text. This is the cheap path behind ModePlain: unlike
+// VTerm it doesn't track cursor movement, so output from programs that
+// redraw in place won't collapse to their final frame the way ModeANSI's
+// does. It shares scanEscape with VTerm.handleEscape so plain and ansi
+// render modes agree on what counts as an escape sequence.
+func Strip(s string) string {
+	b := []byte(s)
+	var out strings.Builder
+	out.Grow(len(b))
+	for i := 0; i < len(b); {
+		if b[i] != 0x1b {
+			out.WriteByte(b[i])
+			i++
+			continue
+		}
+		n, complete, _ := scanEscape(b[i:])
+		if !complete {
+			out.Write(b[i:])
+			break
+		}
+		i += n
+	}
+	return out.String()
+}
+
+// Flatten renders the active screen's final frame as text, re-emitting
+// per-cell SGR state as minimal `\x1b[...m` runs: one escape sequence per
+// contiguous run of cells sharing the same style, not per cell.
+func (v *VTerm) Flatten() string {
+	var b strings.Builder
+	for r, s := range v.screen() {
+		if r > 0 {
+			b.WriteByte('\n')
+		}
+		var cur style
+		open := false
+		for _, c := range trimTrailing(s) {
+			if c.style != cur || !open {
+				if open && !cur.isZero() {
+					b.WriteString("\x1b[0m")
+				}
+				if !c.style.isZero() {
+					b.WriteString(sgrEscape(c.style))
+				}
+				cur = c.style
+				open = true
+			}
+			if c.r == 0 {
+				b.WriteByte(' ')
+			} else {
+				b.WriteRune(c.r)
+			}
+		}
+		if open && !cur.isZero() {
+			b.WriteString("\x1b[0m")
+		}
+	}
+	return b.String()
+}
+
+func trimTrailing(s line) line {
+	end := len(s)
+	for end > 0 && (s[end-1].r == 0 || s[end-1].r == ' ') && s[end-1].style.isZero() {
+		end--
+	}
+	return s[:end]
+}
+
+func sgrEscape(s style) string {
+	codes := []string{}
+	if s.bold {
+		codes = append(codes, "1")
+	}
+	if s.italic {
+		codes = append(codes, "3")
+	}
+	if s.underline {
+		codes = append(codes, "4")
+	}
+	if s.reverse {
+		codes = append(codes, "7")
+	}
+	if s.fg != 0 {
+		codes = append(codes, colorCode(s.fg, false))
+	}
+	if s.bg != 0 {
+		codes = append(codes, colorCode(s.bg, true))
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+}
+
+// colorCode renders a stored color (a plain SGR code, or an opaque
+// extended-color code from parseExtendedColor) back to SGR parameters,
+// selecting the foreground (3x/9x/38) or background (4x/10x/48) family.
+func colorCode(code int, bg bool) string {
+	base := "38"
+	if bg {
+		base = "48"
+	}
+	switch {
+	case code >= 200000:
+		code -= 200000
+		return fmt.Sprintf("%s;2;%d;%d;%d", base, (code>>16)&0xff, (code>>8)&0xff, code&0xff)
+	case code >= 100000:
+		return fmt.Sprintf("%s;5;%d", base, code-100000)
+	default:
+		// Plain SGR codes are already stored in the right family (3x/9x
+		// for fg, 4x/10x for bg) by applySGR.
+		return strconv.Itoa(code)
+	}
+}
@@ -0,0 +1,147 @@
+package ansi
+
+import "testing"
+
+func flatten(t *testing.T, width, height int, raw string) string {
+	t.Helper()
+	v := New(width, height)
+	if _, err := v.Write([]byte(raw)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return v.Flatten()
+}
+
+func TestVTermWriteFlatten(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		raw           string
+		want          string
+	}{
+		{
+			name:  "plain text and newline",
+			width: 10, height: 2,
+			raw:  "hello\nworld",
+			want: "hello\nworld",
+		},
+		{
+			name:  "cursor move overwrites in place",
+			width: 10, height: 1,
+			// Write "xxxx", move left 4, then overwrite with "abcd".
+			raw:  "xxxx\x1b[4Dabcd",
+			want: "abcd",
+		},
+		{
+			name:  "absolute cursor position",
+			width: 10, height: 2,
+			raw:  "\x1b[2;2Hhi",
+			want: "\n hi",
+		},
+		{
+			name:  "erase in line from cursor",
+			width: 10, height: 1,
+			raw:  "hello\r\x1b[K",
+			want: "",
+		},
+		{
+			name:  "erase in line to cursor",
+			width: 10, height: 1,
+			raw:  "hello\x1b[2D\x1b[1K",
+			want: "    o",
+		},
+		{
+			name:  "erase display below cursor",
+			width: 10, height: 3,
+			raw:  "line1\nline2\nline3\x1b[2;1H\x1b[J",
+			want: "line1\n\n",
+		},
+		{
+			name:  "alt screen collapses to main on return",
+			width: 10, height: 1,
+			raw:  "main text\x1b[?1049h\x1b[2Jalt text\x1b[?1049l",
+			want: "main text",
+		},
+		{
+			name:  "alt screen is what's shown while active",
+			width: 10, height: 1,
+			raw:  "main text\x1b[?1049h\x1b[2Jalt text",
+			want: "alt text",
+		},
+		{
+			name:  "256-color round trip",
+			width: 10, height: 1,
+			raw:  "\x1b[38;5;208mhi\x1b[0m",
+			want: "\x1b[38;5;208mhi\x1b[0m",
+		},
+		{
+			name:  "truecolor round trip",
+			width: 10, height: 1,
+			raw:  "\x1b[38;2;10;20;30mhi\x1b[0m",
+			want: "\x1b[38;2;10;20;30mhi\x1b[0m",
+		},
+		{
+			name:  "OSC title is swallowed",
+			width: 10, height: 1,
+			raw:  "\x1b]0;window title\x07hello",
+			want: "hello",
+		},
+		{
+			name:  "charset designator is swallowed",
+			width: 10, height: 1,
+			raw:  "\x1b(Bhello",
+			want: "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flatten(t, tt.width, tt.height, tt.raw)
+			if got != tt.want {
+				t.Errorf("Flatten() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "CSI sequence",
+			in:   "\x1b[31mred\x1b[0m",
+			want: "red",
+		},
+		{
+			name: "OSC sequence terminated by BEL",
+			in:   "\x1b]0;title\x07plain",
+			want: "plain",
+		},
+		{
+			name: "OSC sequence terminated by ST",
+			in:   "\x1b]0;title\x1b\\plain",
+			want: "plain",
+		},
+		{
+			name: "charset designator",
+			in:   "\x1b(Bplain",
+			want: "plain",
+		},
+		{
+			name: "no escape sequences",
+			in:   "just text\nwith a newline",
+			want: "just text\nwith a newline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Strip(tt.in)
+			if got != tt.want {
+				t.Errorf("Strip(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/peterbelm/freeze/ansi"
+)
+
+// Config holds the options that control how a command is executed and
+// rendered before being handed off to Freeze's screenshot pipeline.
+type Config struct {
+	ShowPrompt     bool
+	PromptFormat   string
+	Execute        string
+	ExecuteTimeout time.Duration
+	ExpectTimeout  bool
+
+	// Shell overrides the interpreter used to run Execute. When empty the
+	// platform default is used (bash on Unix, cmd on Windows).
+	Shell string
+
+	// ShutdownGrace is how long executeCommand waits after asking the child
+	// process to exit before force-killing it.
+	ShutdownGrace time.Duration
+
+	// ExecuteRecordPath, if set, writes an asciicast v2 recording of the
+	// executed command's PTY session to this path.
+	ExecuteRecordPath string
+
+	// ExecuteTypescriptPath, if set, writes a script(1)-style typescript of
+	// the executed command's PTY session to this path, alongside a
+	// "<path>.timing" file.
+	ExecuteTypescriptPath string
+
+	// Interactions, if non-empty, scripts the executed command's stdin:
+	// each entry's Expect is matched against PTY output in turn, and its
+	// Send is written to the pty once matched, instead of forwarding the
+	// user's real stdin. Loaded from a file via LoadInteractions.
+	Interactions []Interaction
+
+	// ExecuteStrict fails executeCommand if any Interaction's Expect times
+	// out instead of leaving the session to hang or drift out of sync.
+	ExecuteStrict bool
+
+	// ExecuteRender selects how --execute output is post-processed before
+	// it's returned: raw PTY bytes (ansi.ModeRaw, the default), escape
+	// sequences stripped (ansi.ModePlain), or a clean styled snapshot from
+	// the ansi package's virtual terminal (ansi.ModeANSI).
+	ExecuteRender ansi.Mode
+
+	// PromptTheme selects a named segment theme from the prompt package
+	// (e.g. "minimal", "powerline", "starship-like") to render instead of
+	// expanding PromptFormat's placeholders directly.
+	PromptTheme string
+
+	// PromptExitCode and PromptDuration feed the prompt's [exit-code] and
+	// [duration] segments, mirroring a real shell prompt: they describe the
+	// previously run command, since the prompt line is rendered before
+	// Execute runs. Callers chaining multiple --execute runs in one
+	// session should carry these over from the prior run's result.
+	PromptExitCode int
+	PromptDuration time.Duration
+}
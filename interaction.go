@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultInteractionTimeout is used when an Interaction doesn't set one.
+const defaultInteractionTimeout = 5 * time.Second
+
+// maxMatchBuffer bounds how much of the PTY's recent output a single step
+// scans its Expect pattern against. Without a cap, a chatty command with a
+// slow-to-arrive step would make buf (and so the cost of re-matching it on
+// every Write) grow without bound; the pattern only ever needs to see
+// output from around the time it arrived, so the buffer instead keeps just
+// the tail.
+const maxMatchBuffer = 64 * 1024
+
+// Interaction is one expect/send step of a scripted --execute session:
+// once Expect matches the PTY output, Send is written back to the pty.
+// Expect is a regular expression (a literal string like "Password:" is a
+// valid pattern that matches itself). Send supports \n, \t, \r, and \xHH
+// escapes.
+type Interaction struct {
+	Expect  string        `json:"expect" yaml:"expect"`
+	Send    string        `json:"send" yaml:"send"`
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// LoadInteractions reads a --execute-script file into a slice of
+// Interaction. The format is selected by file extension: .json for JSON,
+// anything else for YAML.
+func LoadInteractions(path string) ([]Interaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read interaction script: %w", err)
+	}
+
+	var interactions []Interaction
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &interactions)
+	} else {
+		err = yaml.Unmarshal(data, &interactions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse interaction script %s: %w", path, err)
+	}
+	return interactions, nil
+}
+
+// interactionMatcher drives a scripted --execute session: it scans PTY
+// output for the current Interaction's Expect pattern, and once matched
+// writes the corresponding Send payload into the pty and advances to the
+// next step. It implements io.Writer so it sits in the same
+// io.MultiWriter chain as stdout, the capture buffer, and any recorders.
+type interactionMatcher struct {
+	pty          io.Writer
+	interactions []Interaction
+	compiled     []*regexp.Regexp // parallel to interactions; nil entry means Expect failed to compile
+	strict       bool
+
+	mu       sync.Mutex
+	idx      int
+	buf      bytes.Buffer
+	deadline time.Time
+
+	failed chan string
+}
+
+func newInteractionMatcher(pty io.Writer, interactions []Interaction, strict bool) *interactionMatcher {
+	compiled := make([]*regexp.Regexp, len(interactions))
+	for i, step := range interactions {
+		// An unparseable pattern can never match; leave it nil and let
+		// Finalize (or the next Write's deadline check) report the failure,
+		// same as a step that simply never matches.
+		compiled[i], _ = regexp.Compile(step.Expect)
+	}
+	m := &interactionMatcher{
+		pty:          pty,
+		interactions: interactions,
+		compiled:     compiled,
+		strict:       strict,
+		failed:       make(chan string, 1),
+	}
+	if len(interactions) > 0 {
+		m.deadline = time.Now().Add(stepTimeout(interactions[0]))
+	}
+	return m
+}
+
+func stepTimeout(step Interaction) time.Duration {
+	if step.Timeout > 0 {
+		return step.Timeout
+	}
+	return defaultInteractionTimeout
+}
+
+// Write scans p (appended to a rolling buffer) for the current step's
+// Expect pattern and, once matched, writes Send to the pty and advances.
+// Under --execute-strict, a step whose deadline has already passed by the
+// time more output arrives is reported as timed out rather than matched
+// against stale buffer contents.
+func (m *interactionMatcher) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.idx >= len(m.interactions) {
+		return len(p), nil
+	}
+	if m.strict && time.Now().After(m.deadline) {
+		m.fail(m.idx)
+		return len(p), nil
+	}
+	m.buf.Write(p)
+	if m.buf.Len() > maxMatchBuffer {
+		tail := m.buf.Bytes()[m.buf.Len()-maxMatchBuffer:]
+		m.buf.Reset()
+		m.buf.Write(tail)
+	}
+
+	step := m.interactions[m.idx]
+	re := m.compiled[m.idx]
+	if re == nil {
+		return len(p), nil
+	}
+	if re.FindStringIndex(m.buf.String()) == nil {
+		return len(p), nil
+	}
+
+	m.buf.Reset()
+	if _, err := io.WriteString(m.pty, unescapeSend(step.Send)); err != nil {
+		return len(p), fmt.Errorf("could not send step %d input: %w", m.idx+1, err)
+	}
+
+	m.idx++
+	if m.idx < len(m.interactions) {
+		m.deadline = time.Now().Add(stepTimeout(m.interactions[m.idx]))
+	}
+	return len(p), nil
+}
+
+// Finalize must be called once the pty has closed and no further output
+// will arrive. A wall-clock timer can't be relied on to fire before a
+// short-lived child process exits, so this is what actually guarantees
+// --execute-strict catches a step that never matched: if one is still
+// pending when the process is done, it's a timeout regardless of whether
+// its deadline happened to elapse first.
+func (m *interactionMatcher) Finalize() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.strict && m.idx < len(m.interactions) {
+		m.fail(m.idx)
+	}
+}
+
+func (m *interactionMatcher) fail(i int) {
+	select {
+	case m.failed <- fmt.Sprintf("step %d: timed out waiting for %q", i+1, m.interactions[i].Expect):
+	default:
+	}
+}
+
+// Err returns the description of the first step whose Expect timed out
+// under --execute-strict, or "" if every step matched (or strict mode is
+// off).
+func (m *interactionMatcher) Err() string {
+	select {
+	case msg := <-m.failed:
+		return msg
+	default:
+		return ""
+	}
+}
+
+// unescapeSend expands \n, \t, \r, and \xHH escapes in a Send payload.
+func unescapeSend(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case 'n':
+			b.WriteByte('\n')
+			i++
+		case 't':
+			b.WriteByte('\t')
+			i++
+		case 'r':
+			b.WriteByte('\r')
+			i++
+		case 'x':
+			if i+3 < len(s) {
+				if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+					b.WriteByte(byte(v))
+					i += 3
+					continue
+				}
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
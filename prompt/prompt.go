@@ -0,0 +1,292 @@
+// Package prompt renders the synthetic prompt line shown above --execute
+// output, from a plain "[user]@[hostname] [wd]$"-style format string up
+// through Powerline/Starship-style segment themes with live git state.
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitTimeout and k8sTimeout bound how long a themed prompt will wait on an
+// external command before giving up on that segment, mirroring the
+// context.WithTimeout pattern executeCommand uses around the command itself.
+const (
+	gitTimeout = 200 * time.Millisecond
+	k8sTimeout = 200 * time.Millisecond
+)
+
+// Powerline separator glyphs, for themes that want the classic arrow look.
+const (
+	PowerlineRight = ""
+	PowerlineLeft  = ""
+)
+
+// Segment is one piece of a themed prompt: which value it shows, its
+// foreground/background color (an ANSI 256-color index), and the
+// separator glyph drawn between it and the next segment.
+type Segment struct {
+	Name      string
+	Fg, Bg    string
+	Separator string
+}
+
+// Theme is a named, ordered list of segments.
+type Theme struct {
+	Name     string
+	Segments []Segment
+}
+
+// Presets ships the themes selectable via --prompt-theme.
+var Presets = map[string]Theme{
+	"minimal":       minimalTheme,
+	"powerline":     powerlineTheme,
+	"starship-like": starshipTheme,
+}
+
+var minimalTheme = Theme{
+	Name: "minimal",
+	Segments: []Segment{
+		{Name: "wd", Fg: "250", Bg: "236"},
+		{Name: "git-branch", Fg: "250", Bg: "236"},
+	},
+}
+
+var powerlineTheme = Theme{
+	Name: "powerline",
+	Segments: []Segment{
+		{Name: "user", Fg: "0", Bg: "148", Separator: PowerlineRight},
+		{Name: "wd", Fg: "15", Bg: "24", Separator: PowerlineRight},
+		{Name: "git-branch", Fg: "0", Bg: "214", Separator: PowerlineRight},
+		{Name: "git-dirty", Fg: "15", Bg: "160", Separator: PowerlineRight},
+	},
+}
+
+var starshipTheme = Theme{
+	Name: "starship-like",
+	Segments: []Segment{
+		{Name: "wd", Fg: "39"},
+		{Name: "git-branch", Fg: "213"},
+		{Name: "git-dirty", Fg: "208"},
+		{Name: "k8s-context", Fg: "63"},
+		{Name: "venv", Fg: "150"},
+		{Name: "duration", Fg: "244"},
+	},
+}
+
+// Vars carries the values a prompt format string or Theme's segments can
+// reference.
+type Vars struct {
+	User     string
+	Hostname string
+	WD       string // display form, e.g. tilde-abbreviated
+	Dir      string // absolute working directory, used for git -C lookups
+	ExitCode int
+	Duration time.Duration
+	Time     time.Time
+}
+
+// Renderer renders prompt strings. It caches the git and Kubernetes
+// segments' shell-outs for its lifetime, so a Renderer should live for one
+// executeCommand run rather than being recreated per placeholder lookup.
+type Renderer struct {
+	gitOnce   sync.Once
+	gitBranch string
+	gitDirty  bool
+
+	k8sOnce sync.Once
+	k8sCtx  string
+}
+
+// NewRenderer returns a Renderer ready to render one run's prompt.
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// ReplaceVars expands [user], [hostname], [wd], [git-branch], [git-dirty],
+// [venv], [k8s-context], [exit-code], [time], and [duration] placeholders
+// in a free-form prompt format string.
+func (r *Renderer) ReplaceVars(format string, v Vars) string {
+	p := format
+	p = strings.ReplaceAll(p, "[user]", v.User)
+	p = strings.ReplaceAll(p, "[hostname]", v.Hostname)
+	p = strings.ReplaceAll(p, "[wd]", v.WD)
+	p = strings.ReplaceAll(p, "[exit-code]", strconv.Itoa(v.ExitCode))
+	p = strings.ReplaceAll(p, "[time]", v.Time.Format("15:04:05"))
+	p = strings.ReplaceAll(p, "[duration]", formatDuration(v.Duration))
+	p = strings.ReplaceAll(p, "[venv]", venvName())
+	if strings.Contains(p, "[k8s-context]") {
+		p = strings.ReplaceAll(p, "[k8s-context]", r.k8sContext())
+	}
+	if strings.Contains(p, "[git-branch]") || strings.Contains(p, "[git-dirty]") {
+		branch, dirty := r.gitState(v.Dir)
+		p = strings.ReplaceAll(p, "[git-branch]", branch)
+		marker := ""
+		if dirty {
+			marker = "*"
+		}
+		p = strings.ReplaceAll(p, "[git-dirty]", marker)
+	}
+	return p
+}
+
+// RenderTheme renders each of theme's segments that has content for v as
+// ANSI-styled text, joined by their separators. Segments with no content
+// (e.g. "git-branch" outside a repo) are skipped, so no separator is drawn
+// against an empty segment; the last rendered segment's separator, if it
+// has one, is drawn against the default background.
+func (r *Renderer) RenderTheme(theme Theme, v Vars) string {
+	type block struct {
+		seg  Segment
+		text string
+	}
+	var blocks []block
+	for _, seg := range theme.Segments {
+		if text, ok := r.segmentText(seg.Name, v); ok {
+			blocks = append(blocks, block{seg, text})
+		}
+	}
+
+	var b strings.Builder
+	for i, blk := range blocks {
+		if i > 0 && blocks[i-1].seg.Separator == "" {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s %s \x1b[0m", sgrEscape(blk.seg.Fg, blk.seg.Bg), blk.text)
+		if blk.seg.Separator == "" {
+			continue
+		}
+		// A Powerline separator is only a solid block when its foreground
+		// is the segment it's leaving and its background is the segment
+		// it's entering; rendering only one color leaves the arrow
+		// floating on the default background.
+		nextBg := ""
+		if i+1 < len(blocks) {
+			nextBg = blocks[i+1].seg.Bg
+		}
+		fmt.Fprintf(&b, "%s%s\x1b[0m", sgrEscape(blk.seg.Bg, nextBg), blk.seg.Separator)
+	}
+	return b.String()
+}
+
+// sgrEscape builds an SGR escape setting only the colors that are
+// actually set. An empty fg or bg is omitted rather than emitted as
+// "38;5;" / "48;5;", which terminals read as color index 0 (black) —
+// not "unset".
+func sgrEscape(fg, bg string) string {
+	var codes []string
+	if fg != "" {
+		codes = append(codes, "38;5;"+fg)
+	}
+	if bg != "" {
+		codes = append(codes, "48;5;"+bg)
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+func (r *Renderer) segmentText(name string, v Vars) (string, bool) {
+	switch name {
+	case "user":
+		return v.User, v.User != ""
+	case "hostname":
+		return v.Hostname, v.Hostname != ""
+	case "wd":
+		return v.WD, v.WD != ""
+	case "exit-code":
+		return strconv.Itoa(v.ExitCode), v.ExitCode != 0
+	case "time":
+		return v.Time.Format("15:04:05"), !v.Time.IsZero()
+	case "duration":
+		d := formatDuration(v.Duration)
+		return d, d != ""
+	case "venv":
+		n := venvName()
+		return n, n != ""
+	case "k8s-context":
+		c := r.k8sContext()
+		return c, c != ""
+	case "git-branch":
+		branch, _ := r.gitState(v.Dir)
+		return branch, branch != ""
+	case "git-dirty":
+		_, dirty := r.gitState(v.Dir)
+		if !dirty {
+			return "", false
+		}
+		return "●", true
+	default:
+		return "", false
+	}
+}
+
+// gitState shells out once, on first use, to find the current branch and
+// whether the working tree is dirty, and caches the result for the rest of
+// this Renderer's life. dir must be a real filesystem path (not a
+// display string like "~/project" — exec does no shell expansion, so
+// git -C would fail on one); an empty dir runs git in the process's own
+// working directory.
+func (r *Renderer) gitState(dir string) (branch string, dirty bool) {
+	r.gitOnce.Do(func() {
+		gitArgs := func(rest ...string) []string {
+			if dir == "" {
+				return rest
+			}
+			return append([]string{"-C", dir}, rest...)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "git", gitArgs("rev-parse", "--abbrev-ref", "HEAD")...).Output()
+		if err != nil {
+			return
+		}
+		r.gitBranch = strings.TrimSpace(string(out))
+
+		ctx2, cancel2 := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel2()
+		status, err := exec.CommandContext(ctx2, "git", gitArgs("status", "--porcelain")...).Output()
+		r.gitDirty = err == nil && strings.TrimSpace(string(status)) != ""
+	})
+	return r.gitBranch, r.gitDirty
+}
+
+// k8sContext shells out once, on first use, to the current kubectl context.
+func (r *Renderer) k8sContext() string {
+	r.k8sOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), k8sTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "kubectl", "config", "current-context").Output()
+		if err == nil {
+			r.k8sCtx = strings.TrimSpace(string(out))
+		}
+	})
+	return r.k8sCtx
+}
+
+func venvName() string {
+	v := os.Getenv("VIRTUAL_ENV")
+	if v == "" {
+		return ""
+	}
+	return filepath.Base(v)
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,77 @@
+package prompt
+
+import "testing"
+
+func TestRenderThemeMinimal(t *testing.T) {
+	r := NewRenderer()
+	v := Vars{WD: "~/proj", Dir: "/nonexistent"}
+	got := r.RenderTheme(minimalTheme, v)
+	want := "\x1b[38;5;250;48;5;236m ~/proj \x1b[0m"
+	if got != want {
+		t.Errorf("RenderTheme(minimal) = %q, want %q", got, want)
+	}
+}
+
+func TestRenderThemePowerlineSeparatorColors(t *testing.T) {
+	r := NewRenderer()
+	v := Vars{User: "ada", WD: "~/proj", Dir: "/nonexistent"}
+	got := r.RenderTheme(powerlineTheme, v)
+	// user -> wd: separator takes user's bg as fg, wd's bg as bg.
+	wantPrefix := "\x1b[38;5;0;48;5;148m ada \x1b[0m\x1b[38;5;148;48;5;24m" + PowerlineRight + "\x1b[0m"
+	if len(got) < len(wantPrefix) || got[:len(wantPrefix)] != wantPrefix {
+		t.Errorf("RenderTheme(powerline) = %q, want prefix %q", got, wantPrefix)
+	}
+}
+
+// TestRenderThemeFgOnlySegments guards against a regression where an empty
+// Bg (as in the starship-like preset, which sets only Fg) produced a
+// malformed "\x1b[48;5;m" SGR code that terminals read as background color
+// index 0 (black), drawing an unwanted box behind every segment.
+func TestRenderThemeFgOnlySegments(t *testing.T) {
+	// Keep the venv and k8s-context segments empty regardless of the test
+	// environment, so only the wd segment renders.
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("KUBECONFIG", "/nonexistent")
+
+	r := NewRenderer()
+	v := Vars{WD: "~/proj", Dir: "/nonexistent"}
+	got := r.RenderTheme(starshipTheme, v)
+	want := "\x1b[38;5;39m ~/proj \x1b[0m"
+	if got != want {
+		t.Errorf("RenderTheme(starship-like) = %q, want %q", got, want)
+	}
+	if want == "" {
+		t.Fatal("test setup produced an empty want string")
+	}
+}
+
+func TestSgrEscape(t *testing.T) {
+	tests := []struct {
+		name   string
+		fg, bg string
+		want   string
+	}{
+		{name: "both set", fg: "39", bg: "236", want: "\x1b[38;5;39;48;5;236m"},
+		{name: "fg only", fg: "39", bg: "", want: "\x1b[38;5;39m"},
+		{name: "bg only", fg: "", bg: "236", want: "\x1b[48;5;236m"},
+		{name: "neither set", fg: "", bg: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sgrEscape(tt.fg, tt.bg)
+			if got != tt.want {
+				t.Errorf("sgrEscape(%q, %q) = %q, want %q", tt.fg, tt.bg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceVars(t *testing.T) {
+	r := NewRenderer()
+	v := Vars{User: "ada", Hostname: "box", WD: "~/proj", ExitCode: 2}
+	got := r.ReplaceVars("[user]@[hostname] [wd] ([exit-code])$", v)
+	want := "ada@box ~/proj (2)$"
+	if got != want {
+		t.Errorf("ReplaceVars() = %q, want %q", got, want)
+	}
+}
@@ -12,16 +12,18 @@ import (
 	"time"
 
 	"github.com/charmbracelet/x/term"
-	"github.com/charmbracelet/x/xpty"
+	"github.com/peterbelm/freeze/ansi"
+	"github.com/peterbelm/freeze/prompt"
+	"github.com/peterbelm/freeze/recorder"
 )
 
 
 func executeCommand(config Config) (string, error) {
 	var promptLine string
 	if config.ShowPrompt {
-		prompt := config.PromptFormat
-		if prompt == "" {
-			prompt = "$"
+		format := config.PromptFormat
+		if format == "" {
+			format = "$"
 		}
 
 		user := os.Getenv("USER")
@@ -34,9 +36,10 @@ func executeCommand(config Config) (string, error) {
 			hostname = "host"
 		}
 
-		wd, err := os.Getwd()
+		dir, err := os.Getwd()
+		wd := dir
 		if err != nil {
-			wd = "~"
+			wd, dir = "~", ""
 		} else {
 			home := os.Getenv("HOME")
 			if home != "" && (wd == home || len(wd) > len(home) && wd[:len(home)] == home && (wd[len(home)] == '/' || len(wd) == len(home))) {
@@ -44,8 +47,27 @@ func executeCommand(config Config) (string, error) {
 			}
 		}
 
-		prompt = replacePromptVars(prompt, user, hostname, wd)
-		promptLine = fmt.Sprintf("%s %s\n", prompt, config.Execute)
+		// wd is tilde-abbreviated for display; dir stays the real absolute
+		// path so segments that shell out (e.g. git -C) get a path exec
+		// can actually resolve.
+		vars := prompt.Vars{
+			User:     user,
+			Hostname: hostname,
+			WD:       wd,
+			Dir:      dir,
+			ExitCode: config.PromptExitCode,
+			Duration: config.PromptDuration,
+			Time:     time.Now(),
+		}
+		renderer := prompt.NewRenderer()
+
+		var line string
+		if theme, ok := prompt.Presets[config.PromptTheme]; ok {
+			line = renderer.RenderTheme(theme, vars)
+		} else {
+			line = renderer.ReplaceVars(format, vars)
+		}
+		promptLine = fmt.Sprintf("%s %s\n", line, config.Execute)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), config.ExecuteTimeout)
@@ -57,13 +79,14 @@ func executeCommand(config Config) (string, error) {
 		height = 24
 	}
 
-	pty, err := xpty.NewPty(width, height)
+	pty, err := newPtyRunner(width, height)
 	if err != nil {
 		return "", fmt.Errorf("could not execute: %w", err)
 	}
 	defer func() { _ = pty.Close() }()
 
-	cmd := exec.CommandContext(ctx, "setsid", "bash", "-lc", config.Execute) //nolint: gosec
+	name, args := shellCommand(config)
+	cmd := exec.Command(name, args...) //nolint: gosec
 	env := os.Environ()
 	
 	// Prevent sudo from opening /dev/tty directly
@@ -98,21 +121,74 @@ func executeCommand(config Config) (string, error) {
 	var errorOut bytes.Buffer
 	var wg sync.WaitGroup
 	wg.Add(1)
-	
-	// Copy stdin to pty for input (don't track in WaitGroup - it may never finish)
-	go func() {
-		_, _ = io.Copy(pty, os.Stdin)
-	}()
-	
-	// Copy pty output to both stdout (for display) and buffer (for capture)
+
+	start := time.Now()
+	writers := []io.Writer{os.Stdout, &out}
+
+	var rec *recorder.Recorder
+	if config.ExecuteRecordPath != "" {
+		f, err := os.Create(config.ExecuteRecordPath)
+		if err != nil {
+			return "", fmt.Errorf("could not create recording: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		rec, err = recorder.New(f, width, height, map[string]string{"SHELL": shellName(config), "TERM": "xterm-256color"}, start)
+		if err != nil {
+			return "", fmt.Errorf("could not start recording: %w", err)
+		}
+		writers = append(writers, rec)
+	}
+
+	if config.ExecuteTypescriptPath != "" {
+		script, err := os.Create(config.ExecuteTypescriptPath)
+		if err != nil {
+			return "", fmt.Errorf("could not create typescript: %w", err)
+		}
+		defer func() { _ = script.Close() }()
+
+		timing, err := os.Create(config.ExecuteTypescriptPath + ".timing")
+		if err != nil {
+			return "", fmt.Errorf("could not create typescript timing file: %w", err)
+		}
+		defer func() { _ = timing.Close() }()
+
+		writers = append(writers, recorder.NewTypescript(script, timing, start))
+	}
+
+	watchResize(ctx, func(w, h int) {
+		_ = pty.Resize(w, h)
+		if rec != nil {
+			_ = rec.Resize(w, h)
+		}
+	})
+
+	var matcher *interactionMatcher
+	if len(config.Interactions) > 0 {
+		// A scripted session drives its own input; it replaces, rather than
+		// reads, the user's stdin.
+		matcher = newInteractionMatcher(pty, config.Interactions, config.ExecuteStrict)
+		writers = append(writers, matcher)
+	} else {
+		// Copy stdin to pty for input (don't track in WaitGroup - it may never finish)
+		go func() {
+			_, _ = io.Copy(pty, os.Stdin)
+		}()
+	}
+
+	// Copy pty output to stdout, the capture buffer, and any recorders
 	go func() {
 		defer wg.Done()
-		multiWriter := io.MultiWriter(os.Stdout, &out)
+		multiWriter := io.MultiWriter(writers...)
 		_, _ = io.Copy(multiWriter, pty)
 		errorOut.Write(out.Bytes())
 	}()
 
-	processErr := xpty.WaitProcess(ctx, cmd)
+	grace := config.ShutdownGrace
+	if grace <= 0 {
+		grace = defaultShutdownGrace
+	}
+	processErr := pty.Wait(ctx, cmd, grace)
 	_ = pty.Close() // Close PTY to allow io.Copy to finish
 	
 	// Wait for output goroutine with a short grace period
@@ -134,21 +210,45 @@ func executeCommand(config Config) (string, error) {
 		// Took too long, continue with what we have
 	}
 	
+	if matcher != nil {
+		matcher.Finalize()
+		if msg := matcher.Err(); msg != "" {
+			return errorOut.String(), fmt.Errorf("could not execute: %s", msg)
+		}
+	}
+
 	if processErr != nil {
 		// If ExpectTimeout is true and the error is a timeout, don't return an error
 		if config.ExpectTimeout && ctx.Err() == context.DeadlineExceeded {
-			cleaned := cleanCommandOutput(out.String())
+			cleaned := renderOutput(config, out.String(), width, height)
 			result := promptLine + cleaned
 			return strings.TrimRight(result, "\n\r"), nil
 		}
 		return errorOut.String(), fmt.Errorf("could not execute: %w", processErr)
 	}
-	cleaned := cleanCommandOutput(out.String())
+	cleaned := renderOutput(config, out.String(), width, height)
 	result := promptLine + cleaned
 	// Remove any trailing newlines from the result
 	return strings.TrimRight(result, "\n\r"), nil
 }
 
+// renderOutput post-processes raw PTY output per config.ExecuteRender:
+// escape sequences left in place (the default), escape sequences stripped,
+// or flattened through the ansi package's virtual terminal into a clean
+// styled snapshot. Every mode then passes through cleanCommandOutput.
+func renderOutput(config Config, raw string, width, height int) string {
+	switch config.ExecuteRender {
+	case ansi.ModePlain:
+		return cleanCommandOutput(ansi.Strip(raw))
+	case ansi.ModeANSI:
+		vt := ansi.New(width, height)
+		_, _ = vt.Write([]byte(raw))
+		return cleanCommandOutput(vt.Flatten())
+	default:
+		return cleanCommandOutput(raw)
+	}
+}
+
 
 // cleanCommandOutput removes leading 'bash: ' error lines and trims trailing empty lines.
 func cleanCommandOutput(s string) string {
@@ -172,12 +272,3 @@ func cleanCommandOutput(s string) string {
 func isBashErrorLine(line string) bool {
 	return len(line) >= 6 && line[:6] == "bash: "
 }
-
-// replacePromptVars replaces [user], [hostname], and [working directory] in the prompt string.
-func replacePromptVars(prompt, user, hostname, wd string) string {
-	p := prompt
-	p = strings.ReplaceAll(p, "[user]", user)
-	p = strings.ReplaceAll(p, "[hostname]", hostname)
-	p = strings.ReplaceAll(p, "[wd]", wd)
-	return p
-}
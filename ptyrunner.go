@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// ptyRunner abstracts the platform-specific details of attaching a command
+// to a pseudo-terminal, so executeCommand can drive a real Unix PTY and a
+// Windows ConPTY session through the same code path.
+type ptyRunner interface {
+	io.Reader
+	io.Writer
+
+	// Start launches cmd attached to the pty.
+	Start(cmd *exec.Cmd) error
+
+	// Resize updates the pty's window size, e.g. in response to SIGWINCH.
+	Resize(width, height int) error
+
+	// Wait blocks until cmd exits. If ctx is canceled first, it tears the
+	// process down gracefully: a platform-appropriate "please exit" signal,
+	// then a force-kill after grace if the process hasn't responded.
+	Wait(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error
+
+	// Close releases the pty.
+	Close() error
+}
+
+// newPtyRunner creates the ptyRunner for the current platform.
+func newPtyRunner(width, height int) (ptyRunner, error) {
+	return newPlatformPtyRunner(width, height)
+}
+
+// defaultShutdownGrace is used when Config.ShutdownGrace is unset.
+const defaultShutdownGrace = 3 * time.Second
@@ -0,0 +1,93 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/charmbracelet/x/xpty"
+)
+
+// unixRunner drives a PTY on Linux/macOS/BSD via xpty, the path Freeze has
+// always used.
+type unixRunner struct {
+	pty xpty.Pty
+}
+
+func newPlatformPtyRunner(width, height int) (ptyRunner, error) {
+	pty, err := xpty.NewPty(width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &unixRunner{pty: pty}, nil
+}
+
+func (r *unixRunner) Start(cmd *exec.Cmd) error   { return r.pty.Start(cmd) }
+func (r *unixRunner) Read(p []byte) (int, error)  { return r.pty.Read(p) }
+func (r *unixRunner) Write(p []byte) (int, error) { return r.pty.Write(p) }
+func (r *unixRunner) Resize(w, h int) error       { return r.pty.Resize(w, h) }
+func (r *unixRunner) Close() error                { return r.pty.Close() }
+
+// Wait blocks until cmd exits, or tears the process group down if ctx is
+// canceled first: SIGTERM to the whole group (cmd runs under setsid), then
+// SIGKILL after grace.
+func (r *unixRunner) Wait(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- xpty.WaitProcess(context.Background(), cmd) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(grace):
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			return <-done
+		}
+	}
+}
+
+// shellCommand returns the program and arguments used to run config.Execute
+// under a PTY on this platform.
+func shellCommand(config Config) (string, []string) {
+	return "setsid", []string{shellName(config), "-lc", config.Execute}
+}
+
+// shellName returns the actual interpreter shellCommand runs config.Execute
+// under — distinct from the "setsid" wrapper program — for metadata like
+// recording headers.
+func shellName(config Config) string {
+	if config.Shell != "" {
+		return config.Shell
+	}
+	return "bash"
+}
+
+// watchResize invokes resize with the new terminal geometry every time
+// SIGWINCH fires, until ctx is done.
+func watchResize(ctx context.Context, resize func(width, height int)) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil {
+					resize(w, h)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"time"
+
+	"github.com/charmbracelet/x/xpty"
+)
+
+// windowsRunner drives a Windows pseudoconsole (ConPTY) via xpty. Unlike the
+// Unix path, exec.CommandContext's cancellation can't reach into the
+// pseudoconsole, so ctx cancellation is handled entirely here.
+type windowsRunner struct {
+	pty xpty.Pty
+}
+
+func newPlatformPtyRunner(width, height int) (ptyRunner, error) {
+	pty, err := xpty.NewPty(width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &windowsRunner{pty: pty}, nil
+}
+
+func (r *windowsRunner) Start(cmd *exec.Cmd) error   { return r.pty.Start(cmd) }
+func (r *windowsRunner) Read(p []byte) (int, error)  { return r.pty.Read(p) }
+func (r *windowsRunner) Write(p []byte) (int, error) { return r.pty.Write(p) }
+func (r *windowsRunner) Resize(w, h int) error       { return r.pty.Resize(w, h) }
+func (r *windowsRunner) Close() error                { return r.pty.Close() }
+
+// Wait blocks until cmd exits, or tears it down if ctx is canceled first.
+// ConPTY has no SIGTERM equivalent, so the best-effort "ask nicely" step is
+// closing the pty (which closes the console's stdin); anything still alive
+// after grace is force-killed.
+func (r *windowsRunner) Wait(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = r.pty.Close()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(grace):
+			_ = cmd.Process.Kill()
+			return <-done
+		}
+	}
+}
+
+// shellCommand returns the program and arguments used to run config.Execute
+// under a PTY on this platform: cmd.exe by default, or powershell / a
+// user-supplied interpreter via --shell.
+func shellCommand(config Config) (string, []string) {
+	switch config.Shell {
+	case "powershell", "pwsh":
+		return config.Shell, []string{"-NoProfile", "-Command", config.Execute}
+	case "", "cmd":
+		return "cmd", []string{"/c", config.Execute}
+	default:
+		return config.Shell, []string{"-Command", config.Execute}
+	}
+}
+
+// shellName returns the interpreter shellCommand runs config.Execute under,
+// for metadata like recording headers. Unlike the Unix path, there's no
+// wrapper process here: it's the same program shellCommand launches.
+func shellName(config Config) string {
+	name, _ := shellCommand(config)
+	return name
+}
+
+// watchResize is a no-op on Windows: consoles don't deliver SIGWINCH, and
+// ConPTY's own resize notifications aren't wired up here yet.
+func watchResize(ctx context.Context, resize func(width, height int)) {}
@@ -0,0 +1,99 @@
+// Package recorder captures a PTY session as a replayable transcript,
+// either as an asciicast v2 file or a script(1)-style typescript/timing
+// pair, while it streams to the terminal and into Freeze's capture buffer.
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// header is the asciicast v2 header line.
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes PTY output as an asciicast v2 event stream: a header line
+// followed by one `[elapsed, "o", data]` line per write, plus `["r", ...]`
+// resize events.
+type Recorder struct {
+	w     io.Writer
+	start time.Time
+}
+
+// New writes the asciicast v2 header to w and returns a Recorder ready to
+// accept events. width and height are the pty's starting geometry; env is
+// recorded for replay fidelity (e.g. TERM, SHELL).
+func New(w io.Writer, width, height int, env map[string]string, start time.Time) (*Recorder, error) {
+	h := header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Env:       env,
+	}
+	enc, err := json.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode asciicast header: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", enc); err != nil {
+		return nil, fmt.Errorf("could not write asciicast header: %w", err)
+	}
+	return &Recorder{w: w, start: start}, nil
+}
+
+// Write records p as an "o" (output) event. It implements io.Writer so a
+// Recorder can be passed straight into io.MultiWriter alongside stdout and
+// the capture buffer.
+func (r *Recorder) Write(p []byte) (int, error) {
+	if err := r.event("o", string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize records an "r" (resize) event, e.g. in response to SIGWINCH.
+func (r *Recorder) Resize(width, height int) error {
+	return r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *Recorder) event(kind, data string) error {
+	enc, err := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	if err != nil {
+		return fmt.Errorf("could not encode asciicast event: %w", err)
+	}
+	_, err = fmt.Fprintf(r.w, "%s\n", enc)
+	return err
+}
+
+// Typescript writes a script(1)-compatible typescript + timing file pair:
+// raw bytes to script, and "<delta-seconds> <byte-count>" lines to timing.
+type Typescript struct {
+	script io.Writer
+	timing io.Writer
+	last   time.Time
+}
+
+// NewTypescript returns a Typescript recorder that stamps its first delta
+// from start.
+func NewTypescript(script, timing io.Writer, start time.Time) *Typescript {
+	return &Typescript{script: script, timing: timing, last: start}
+}
+
+// Write implements io.Writer, recording the delta since the previous write
+// (or since the recorder was created) before appending p to the script.
+func (t *Typescript) Write(p []byte) (int, error) {
+	now := time.Now()
+	delta := now.Sub(t.last).Seconds()
+	t.last = now
+	if _, err := fmt.Fprintf(t.timing, "%f %d\n", delta, len(p)); err != nil {
+		return 0, fmt.Errorf("could not write typescript timing: %w", err)
+	}
+	return t.script.Write(p)
+}